@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// equirectangularBBox liefert eine grobe Bounding-Box um (lat, lon) mit
+// gegebenem Radius in Metern, basierend auf einer äquirechteckigen Näherung
+func equirectangularBBox(lat, lon, radiusMeters float64) (minLon, maxLon, minLat, maxLat float64) {
+	latDelta := radiusMeters / 111320.0
+	lonDelta := radiusMeters / (111320.0 * math.Cos(lat*math.Pi/180))
+	return lon - lonDelta, lon + lonDelta, lat - latDelta, lat + latDelta
+}
+
+// ReverseGeocode sucht über den addresses_rtree-Index nach Adressen innerhalb
+// von radiusMeters um (lat, lon), verfeinert die Kandidaten per
+// Haversine-Distanz und liefert sie aufsteigend sortiert, begrenzt auf limit
+// Einträge (limit <= 0 bedeutet unbegrenzt).
+func ReverseGeocode(db *sql.DB, lat, lon, radiusMeters float64, limit int) ([]AddressRecord, error) {
+	minLon, maxLon, minLat, maxLat := equirectangularBBox(lat, lon, radiusMeters)
+
+	candidates, err := queryRTreeCandidates(db, minLon, maxLon, minLat, maxLat)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := refineByDistance(candidates, lat, lon, radiusMeters)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return toAddressRecords(matches), nil
+}
+
+// NearestN sucht die n nächstgelegenen Adressen zu (lat, lon), indem die
+// Bounding-Box des R*Tree so lange verdoppelt wird, bis genügend Kandidaten
+// gefunden wurden oder maxRadius erreicht ist.
+func NearestN(db *sql.DB, lat, lon float64, n int) ([]AddressRecord, error) {
+	const startRadius = 500.0
+	const maxRadius = 100_000.0
+
+	for radius := startRadius; ; radius *= 2 {
+		minLon, maxLon, minLat, maxLat := equirectangularBBox(lat, lon, radius)
+
+		candidates, err := queryRTreeCandidates(db, minLon, maxLon, minLat, maxLat)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(candidates) >= n || radius >= maxRadius {
+			matches := refineByDistance(candidates, lat, lon, math.Inf(1))
+			if len(matches) > n {
+				matches = matches[:n]
+			}
+			return toAddressRecords(matches), nil
+		}
+	}
+}
+
+// refineByDistance berechnet die Haversine-Distanz jedes Kandidaten zu
+// (lat, lon), verwirft alles außerhalb von maxDistance und sortiert
+// aufsteigend nach Distanz.
+func refineByDistance(candidates []AddressRecord, lat, lon, maxDistance float64) []addressMatch {
+	matches := make([]addressMatch, 0, len(candidates))
+	for _, rec := range candidates {
+		distance := haversineMeters(lat, lon, rec.Lat, rec.Lon)
+		if distance <= maxDistance {
+			matches = append(matches, addressMatch{AddressRecord: rec, Distance: distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Distance < matches[j].Distance })
+	return matches
+}
+
+func toAddressRecords(matches []addressMatch) []AddressRecord {
+	records := make([]AddressRecord, len(matches))
+	for i, m := range matches {
+		records[i] = m.AddressRecord
+	}
+	return records
+}
+
+// queryRTreeCandidates liefert alle Adressen, deren addresses_rtree-Eintrag
+// die gegebene Bounding-Box überschneidet
+func queryRTreeCandidates(db *sql.DB, minLon, maxLon, minLat, maxLat float64) ([]AddressRecord, error) {
+	rows, err := db.Query(`
+		SELECT a.street, a.house_number, a.city, a.longitude, a.latitude
+		FROM addresses_rtree r
+		JOIN addresses a ON a.id = r.id
+		WHERE r.min_lon <= ? AND r.max_lon >= ?
+		AND r.min_lat <= ? AND r.max_lat >= ?
+	`, maxLon, minLon, maxLat, minLat)
+	if err != nil {
+		return nil, fmt.Errorf("fehler bei der R*Tree-abfrage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []AddressRecord
+	for rows.Next() {
+		var rec AddressRecord
+		if err := rows.Scan(&rec.Street, &rec.HouseNumber, &rec.City, &rec.Lon, &rec.Lat); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEquirectangularBBox(t *testing.T) {
+	tests := []struct {
+		name         string
+		lat, lon     float64
+		radiusMeters float64
+	}{
+		{"equator", 0, 0, 1000},
+		{"mid-latitude", 49.45, 11.08, 500},
+		{"near pole", 80, 20, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minLon, maxLon, minLat, maxLat := equirectangularBBox(tt.lat, tt.lon, tt.radiusMeters)
+
+			if minLon >= maxLon || minLat >= maxLat {
+				t.Fatalf("bbox ist degeneriert: minLon=%v maxLon=%v minLat=%v maxLat=%v", minLon, maxLon, minLat, maxLat)
+			}
+			if tt.lon < minLon || tt.lon > maxLon || tt.lat < minLat || tt.lat > maxLat {
+				t.Fatalf("ausgangspunkt (%v, %v) liegt nicht innerhalb der eigenen bbox", tt.lat, tt.lon)
+			}
+
+			// Die Breitengrad-Spanne ist unabhängig vom Breitengrad selbst,
+			// die Längengrad-Spanne wird mit zunehmendem |lat| breiter.
+			wantLatDelta := tt.radiusMeters / 111320.0
+			if math.Abs((maxLat-minLat)/2-wantLatDelta) > 1e-9 {
+				t.Errorf("latDelta = %v, want %v", (maxLat-minLat)/2, wantLatDelta)
+			}
+		})
+	}
+}
+
+func TestRefineByDistanceFiltersAndSorts(t *testing.T) {
+	// München als Referenzpunkt, mit Kandidaten in steigender Entfernung
+	lat, lon := 48.1372, 11.5755
+
+	near := AddressRecord{Street: "near", Lat: 48.1373, Lon: 11.5756}
+	medium := AddressRecord{Street: "medium", Lat: 48.15, Lon: 11.58}
+	far := AddressRecord{Street: "far", Lat: 49.45, Lon: 11.08}
+
+	candidates := []AddressRecord{far, near, medium}
+
+	matches := refineByDistance(candidates, lat, lon, 5000)
+
+	if len(matches) != 2 {
+		t.Fatalf("len(matches) = %d, want 2 (far sollte durch maxDistance ausgeschlossen werden)", len(matches))
+	}
+	if matches[0].Street != "near" || matches[1].Street != "medium" {
+		t.Fatalf("matches nicht aufsteigend nach distanz sortiert: got %q, %q", matches[0].Street, matches[1].Street)
+	}
+	if matches[0].Distance >= matches[1].Distance {
+		t.Errorf("matches[0].Distance (%v) sollte kleiner als matches[1].Distance (%v) sein", matches[0].Distance, matches[1].Distance)
+	}
+}
+
+func TestRefineByDistanceUnbounded(t *testing.T) {
+	candidates := []AddressRecord{
+		{Street: "a", Lat: 48.1372, Lon: 11.5755},
+		{Street: "b", Lat: 49.45, Lon: 11.08},
+	}
+
+	matches := refineByDistance(candidates, 48.1372, 11.5755, math.Inf(1))
+	if len(matches) != 2 {
+		t.Fatalf("len(matches) = %d, want 2 bei maxDistance=+Inf", len(matches))
+	}
+}
+
+// setupGeocodeTestDB legt ein minimales addresses/addresses_rtree-Schema in
+// einer In-Memory-Datenbank an, wie es processGeoJSON für echte Imports
+// erzeugt, und befüllt es mit den übergebenen Datensätzen.
+func setupGeocodeTestDB(t *testing.T, records []AddressRecord) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`
+		CREATE TABLE addresses (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			street TEXT, house_number TEXT, city TEXT,
+			longitude REAL, latitude REAL
+		);
+		CREATE VIRTUAL TABLE addresses_rtree USING rtree(id, min_lon, max_lon, min_lat, max_lat);
+	`); err != nil {
+		t.Fatalf("schema anlegen: %v", err)
+	}
+
+	for _, rec := range records {
+		res, err := db.Exec(
+			"INSERT INTO addresses (street, house_number, city, longitude, latitude) VALUES (?, ?, ?, ?, ?)",
+			rec.Street, rec.HouseNumber, rec.City, rec.Lon, rec.Lat,
+		)
+		if err != nil {
+			t.Fatalf("insert addresses: %v", err)
+		}
+		id, _ := res.LastInsertId()
+		if _, err := db.Exec(
+			"INSERT INTO addresses_rtree(id, min_lon, max_lon, min_lat, max_lat) VALUES (?, ?, ?, ?, ?)",
+			id, rec.Lon, rec.Lon, rec.Lat, rec.Lat,
+		); err != nil {
+			t.Fatalf("insert addresses_rtree: %v", err)
+		}
+	}
+
+	return db
+}
+
+func TestReverseGeocodeRadius(t *testing.T) {
+	// München-Zentrum als Ziel, ein naher und ein weit entfernter Treffer
+	db := setupGeocodeTestDB(t, []AddressRecord{
+		{Street: "Marienplatz", Lon: 11.5755, Lat: 48.1372},
+		{Street: "Nürnberg", Lon: 11.08, Lat: 49.45},
+	})
+
+	matches, err := ReverseGeocode(db, 48.1372, 11.5755, 1000, 0)
+	if err != nil {
+		t.Fatalf("ReverseGeocode: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Street != "Marienplatz" {
+		t.Fatalf("matches = %+v, want nur Marienplatz innerhalb 1km", matches)
+	}
+}
+
+func TestNearestNExpandsUntilEnoughCandidates(t *testing.T) {
+	// Beide Adressen liegen außerhalb des Start-Suchradius von NearestN
+	// (500m), sollen aber nach Verdopplung des Radius gefunden werden.
+	db := setupGeocodeTestDB(t, []AddressRecord{
+		{Street: "Marienplatz", Lon: 11.5755, Lat: 48.1372},
+		{Street: "Odeonsplatz", Lon: 11.5765, Lat: 48.1425},
+	})
+
+	matches, err := NearestN(db, 48.1372, 11.5755, 2)
+	if err != nil {
+		t.Fatalf("NearestN: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("len(matches) = %d, want 2", len(matches))
+	}
+	if matches[0].Street != "Marienplatz" {
+		t.Errorf("matches[0] = %q, want nächstgelegene adresse zuerst", matches[0].Street)
+	}
+}
+
+func TestNearestNLimitsResultCount(t *testing.T) {
+	db := setupGeocodeTestDB(t, []AddressRecord{
+		{Street: "a", Lon: 11.5755, Lat: 48.1372},
+		{Street: "b", Lon: 11.5756, Lat: 48.1373},
+		{Street: "c", Lon: 11.5757, Lat: 48.1374},
+	})
+
+	matches, err := NearestN(db, 48.1372, 11.5755, 1)
+	if err != nil {
+		t.Fatalf("NearestN: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("len(matches) = %d, want 1", len(matches))
+	}
+}
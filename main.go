@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
-	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -52,12 +54,37 @@ type AddressRecord struct {
 	City        string
 	Lon         float64
 	Lat         float64
+	OSMType     string
+	OSMID       int64
 }
 
 func main() {
+	// Erstes Argument als Subcommand interpretieren (z.B. "update"), alles
+	// andere fällt zurück auf den klassischen Import-Workflow
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		switch os.Args[1] {
+		case "update":
+			if err := cmdUpdate(os.Args[2:]); err != nil {
+				log.Fatalf("❌ Fehler: %v", err)
+			}
+			return
+		case "serve":
+			if err := cmdServe(os.Args[2:]); err != nil {
+				log.Fatalf("❌ Fehler: %v", err)
+			}
+			return
+		case "run":
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		default:
+			log.Fatalf("❌ Fehler: unbekanntes Subcommand %q", os.Args[1])
+		}
+	}
+
 	// Kommandozeilenargumente verarbeiten
 	var url string
+	var mappingPath string
 	flag.StringVar(&url, "url", defaultDataURL, "URL der OSM-Datei zum Herunterladen")
+	flag.StringVar(&mappingPath, "mapping", "", "Pfad zu einer YAML/JSON-Mapping-Datei (Standard: festes addresses-Schema)")
 	flag.Parse()
 
 	// Setze die dataURL mit dem übergebenen Wert oder dem Standardwert
@@ -76,40 +103,42 @@ func main() {
 		dbFile = fileName[:strings.LastIndex(fileName, ".")] + ".db"
 	}
 
-	err := run()
+	var mapping *MappingConfig
+	if mappingPath != "" {
+		loaded, err := loadMappingConfig(mappingPath)
+		if err != nil {
+			log.Fatalf("❌ Fehler beim laden der mapping-datei: %v", err)
+		}
+		mapping = loaded
+	} else {
+		mapping = defaultMapping()
+	}
+
+	err := run(mapping)
 	if err != nil {
 		log.Fatalf("❌ Fehler: %v", err)
 	}
 }
 
-func run() error {
+func run(mapping *MappingConfig) error {
 	// OSM-Datei herunterladen
 	if err := downloadOSMFile(); err != nil {
 		return fmt.Errorf("fehler beim herunterladen der osm-datei: %w", err)
 	}
 
-	// OSM-Daten filtern
-	if err := filterOSMData(); err != nil {
-		return fmt.Errorf("fehler beim filtern der osm-daten: %w", err)
-	}
-
-	// Zu GeoJSON exportieren
-	if _, err := os.Stat("filtered.osm.pbf"); os.IsNotExist(err) {
-		return fmt.Errorf("fehler: gefilterte osm-datei existiert nicht")
-	}
-
-	if err := exportToGeoJSON(); err != nil {
-		return fmt.Errorf("fehler geojson: %w", err)
-	}
-
-	// Nach SQLite konvertieren
-	if err := processGeoJSON(); err != nil {
-		return fmt.Errorf("fehler geo to sqlite: %w", err)
+	// Direkt aus der PBF-Datei nach SQLite importieren, ohne den Umweg über
+	// osmium und eine GeoJSON-Zwischendatei
+	if err := processPBF(fileName, mapping); err != nil {
+		return fmt.Errorf("fehler beim importieren der pbf-datei: %w", err)
 	}
 
-	// Volltextsuche demonstrieren
-	if err := searchAddresses(); err != nil {
-		return fmt.Errorf("fehler bei der volltextsuche: %w", err)
+	// Volltextsuche demonstrieren, sofern das Mapping überhaupt eine
+	// addresses-Tabelle anlegt (bei einem reinen pois/highways-Mapping gibt
+	// es weder address_fts noch addresses)
+	if mapping.hasTable("addresses") {
+		if err := searchAddresses(); err != nil {
+			return fmt.Errorf("fehler bei der volltextsuche: %w", err)
+		}
 	}
 
 	return nil
@@ -152,60 +181,8 @@ func downloadOSMFile() error {
 	return nil
 }
 
-func filterOSMData() error {
-	filteredFile := "filtered.osm.pbf"
-
-	if _, err := os.Stat(filteredFile); err == nil {
-		fmt.Println("✔ Gefilterte OSM-Datei existiert bereits.")
-		return nil
-	}
-
-	fmt.Println("🔍 Filtere OSM-Daten nach Adressen...")
-
-	cmd := exec.Command("osmium", "tags-filter", "-o", filteredFile, fileName, "nwr/addr:street", "-f", "pbf")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("✅ Filterung abgeschlossen.")
-	return nil
-}
-
-func exportToGeoJSON() error {
-	fmt.Println("📊 Exportiere Daten nach GeoJSON...")
-
-	// Überprüfen ob die Datei bereits existiert
-	if _, err := os.Stat("filtered.geojson"); err == nil {
-		fmt.Println("✔ GeoJSON-Datei existiert bereits.")
-		return nil
-	}
-
-	cmd := exec.Command("osmium", "export", "filtered.osm.pbf", "-f", "geojson", "--output=filtered.geojson")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("✅ Export nach GeoJSON abgeschlossen.")
-	return nil
-}
-
-func processGeoJSON() error {
-	fmt.Println("🛠 Verarbeite GeoJSON zu SQLite...")
-
-	// GeoJSON-Datei öffnen
-	file, err := os.Open("filtered.geojson")
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+func processPBF(pbfFile string, mapping *MappingConfig) error {
+	fmt.Println("🛠 Importiere PBF-Datei direkt nach SQLite...")
 
 	// SQLite-Datenbank erstellen mit noch stärker optimierten Parametern
 	os.Remove(dbFile) // Falls die Datei bereits existiert
@@ -239,29 +216,12 @@ func processGeoJSON() error {
 		}
 	}
 
-	// Tabelle erstellen ohne Indizes
-	createTableSQL := `
-	CREATE TABLE addresses (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		street TEXT,
-		house_number TEXT,
-		city TEXT,
-		longitude REAL,
-		latitude REAL,
-		UNIQUE(street, house_number, city)
-	);
-	`
-	_, err = db.Exec(createTableSQL)
-	if err != nil {
-		return err
-	}
-
-	// Feature Collection decodieren
-	decoder := json.NewDecoder(file)
-
-	// Das erste Token ist '{' - überspringen
-	_, err = decoder.Token()
-	if err != nil {
+	// Tabellen gemäß Mapping-Konfiguration anlegen. "addresses" behält dabei
+	// exakt das bisherige Schema bei, damit update.go/serve.go/geocode.go
+	// (FTS-Tabelle address_fts, R*Tree addresses_rtree) unverändert weiter
+	// funktionieren; zusätzliche Mapping-Tabellen erhalten ein generisches
+	// Schema.
+	if err := createMappedTables(db, mapping); err != nil {
 		return err
 	}
 
@@ -282,171 +242,313 @@ func processGeoJSON() error {
 	batchStartTime := time.Now()
 	maxBatchDuration := 5 * time.Second // Commit spätestens alle 5 Sekunden
 
-	// Batch für Bulk-Insert vorbereiten
-	batch := make([]*AddressRecord, 0, batchSize)
+	// Je Tabelle ein eigener Batch, da onRecord pro Treffer einmal je
+	// passender Tabelle aufgerufen wird
+	addressBatch := make([]*AddressRecord, 0, batchSize)
+	otherBatches := make(map[string][]mappedRecord)
 
-	// JSON-Tokens durchlaufen
-	for decoder.More() {
-		token, err := decoder.Token()
-		if err != nil {
-			return err
+	flushBatches := func() error {
+		if len(addressBatch) > 0 {
+			if err := bulkInsert(tx, addressBatch); err != nil {
+				return err
+			}
+			addressBatch = addressBatch[:0]
 		}
 
-		// Suche nach "features"-Key
-		if key, ok := token.(string); ok && key == "features" {
-			// Array öffnen - '[' - überspringen
-			_, err = decoder.Token()
-			if err != nil {
+		for name, recs := range otherBatches {
+			if len(recs) == 0 {
+				continue
+			}
+			if err := bulkInsertMapped(tx, mapping.tableByName(name), recs); err != nil {
 				return err
 			}
+			otherBatches[name] = recs[:0]
+		}
 
-			// Features verarbeiten
-			for decoder.More() {
-				var feature Feature
-				err = decoder.Decode(&feature)
-				if err != nil {
-					continue
-				}
+		return nil
+	}
 
-				// Adressdaten extrahieren
-				record, ok := extractAddressData(&feature)
-				if ok {
-					batch = append(batch, record)
-					totalCount++
-
-					// Periodisches Commit für große Datensätze
-					if len(batch) >= batchSize || time.Since(batchStartTime) >= maxBatchDuration {
-						err = bulkInsert(tx, batch)
-						if err != nil {
-							return err
-						}
-
-						err = tx.Commit()
-						if err != nil {
-							return err
-						}
-
-						// Performance-Statistiken berechnen
-						now := time.Now()
-						elapsedSinceLastReport := now.Sub(lastReportTime).Seconds()
-						if elapsedSinceLastReport > 0 {
-							recordsPerSecond = float64(len(batch)) / elapsedSinceLastReport
-							lastReportTime = now
-						}
-
-						estimatedTotal := float64(33000000) // Geschätzte Gesamtanzahl
-						remainingRecords := estimatedTotal - float64(totalCount)
-
-						var estimatedRemaining string
-						if recordsPerSecond > 0 {
-							remainingSeconds := remainingRecords / recordsPerSecond
-							estimatedRemaining = fmt.Sprintf("%.1f Minuten verbleibend", remainingSeconds/60)
-						} else {
-							estimatedRemaining = "berechne..."
-						}
-
-						fmt.Printf("Verarbeitet: %d Adressen (%.1f/Sek, %.1f%%, %s)...\n",
-							totalCount,
-							recordsPerSecond,
-							float64(totalCount)/estimatedTotal*100,
-							estimatedRemaining)
-
-						// Neue Transaktion starten
-						tx, err = db.Begin()
-						if err != nil {
-							return err
-						}
-
-						batch = batch[:0]
-						batchStartTime = time.Now()
-					}
-				}
+	// PBF-Datei streamend durchlaufen, pro Mapping-Tabelle Datensätze direkt verarbeiten
+	err = scanPBFMapped(pbfFile, mapping, func(table TableMapping, rec mappedRecord) error {
+		if table.Name == "addresses" {
+			addressBatch = append(addressBatch, &AddressRecord{
+				Street:      rec.Values["street"],
+				HouseNumber: rec.Values["house_number"],
+				City:        rec.Values["city"],
+				Lon:         rec.Lon,
+				Lat:         rec.Lat,
+				OSMType:     rec.OSMType,
+				OSMID:       rec.OSMID,
+			})
+		} else {
+			otherBatches[table.Name] = append(otherBatches[table.Name], rec)
+		}
+		totalCount++
+
+		// Periodisches Commit für große Datensätze
+		if len(addressBatch) >= batchSize || time.Since(batchStartTime) >= maxBatchDuration {
+			if err := flushBatches(); err != nil {
+				return err
 			}
 
-			// Letzte Transaktion committen
-			if len(batch) > 0 {
-				err = bulkInsert(tx, batch)
-				if err != nil {
-					return err
-				}
+			if err := tx.Commit(); err != nil {
+				return err
+			}
 
-				err = tx.Commit()
-				if err != nil {
-					return err
-				}
+			// Performance-Statistiken berechnen
+			now := time.Now()
+			elapsedSinceLastReport := now.Sub(lastReportTime).Seconds()
+			if elapsedSinceLastReport > 0 {
+				recordsPerSecond = float64(totalCount) / now.Sub(startTime).Seconds()
+				lastReportTime = now
+			}
+
+			estimatedTotal := float64(33000000) // Geschätzte Gesamtanzahl
+			remainingRecords := estimatedTotal - float64(totalCount)
+
+			var estimatedRemaining string
+			if recordsPerSecond > 0 {
+				remainingSeconds := remainingRecords / recordsPerSecond
+				estimatedRemaining = fmt.Sprintf("%.1f Minuten verbleibend", remainingSeconds/60)
+			} else {
+				estimatedRemaining = "berechne..."
+			}
+
+			fmt.Printf("Verarbeitet: %d Datensätze (%.1f/Sek, %.1f%%, %s)...\n",
+				totalCount,
+				recordsPerSecond,
+				float64(totalCount)/estimatedTotal*100,
+				estimatedRemaining)
+
+			// Neue Transaktion starten
+			var err error
+			tx, err = db.Begin()
+			if err != nil {
+				return err
 			}
+
+			batchStartTime = time.Now()
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Nach dem Import die Indizes erstellen
-	fmt.Println("📊 Erstelle Indizes...")
+	// Letzte Transaktion committen
+	if err := flushBatches(); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 
-	// Indizes parallel erstellen für bessere Performance
-	createIndices := []string{
-		"CREATE INDEX idx_city ON addresses(city)",
-		"CREATE INDEX idx_street ON addresses(street)",
-		"CREATE INDEX idx_street_house ON addresses(street, house_number)",
+	// Nach dem Import Indizes, FTS5 und (nur für addresses) den R*Tree-Index
+	// erstellen
+	if err := finalizeMappedTables(db, mapping); err != nil {
+		return err
 	}
 
-	for _, indexSQL := range createIndices {
-		_, err = db.Exec(indexSQL)
-		if err != nil {
+	// Statistiken ausgeben
+	totalTime := time.Since(startTime).Seconds()
+	fmt.Printf("✅ Fertig. %d Datensätze wurden in %.1f Sekunden importiert (%.1f Einträge/Sek).\n",
+		totalCount,
+		totalTime,
+		float64(totalCount)/totalTime,
+	)
+
+	return nil
+}
+
+// createMappedTables legt für jede Tabelle des Mapping das passende Schema
+// an. "addresses" erhält das bisherige, fest verdrahtete Schema; alle
+// anderen Tabellen ein generisches Schema aus ihren Mapping-Spalten.
+func createMappedTables(db *sql.DB, mapping *MappingConfig) error {
+	for _, table := range mapping.Tables {
+		if table.Name == "addresses" {
+			createTableSQL := `
+			CREATE TABLE addresses (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				street TEXT,
+				house_number TEXT,
+				city TEXT,
+				longitude REAL,
+				latitude REAL,
+				osm_type CHAR(1),
+				osm_id INTEGER,
+				UNIQUE(street, house_number, city)
+			);
+			CREATE UNIQUE INDEX idx_addresses_osm ON addresses(osm_type, osm_id);
+			`
+			if _, err := db.Exec(createTableSQL); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var columns strings.Builder
+		for _, column := range table.Columns {
+			fmt.Fprintf(&columns, "%s %s,\n", column.Name, column.Type)
+		}
+
+		createTableSQL := fmt.Sprintf(`
+		CREATE TABLE %s (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			%s
+			longitude REAL,
+			latitude REAL,
+			osm_type CHAR(1),
+			osm_id INTEGER,
+			UNIQUE(osm_type, osm_id)
+		);
+		`, table.Name, columns.String())
+		if _, err := db.Exec(createTableSQL); err != nil {
+			return fmt.Errorf("fehler beim anlegen der tabelle %q: %w", table.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// bulkInsertMapped fügt Datensätze einer generischen Mapping-Tabelle ein
+func bulkInsertMapped(tx *sql.Tx, table TableMapping, records []mappedRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	const maxRecordsPerBatch = 500
+
+	columnNames := make([]string, 0, len(table.Columns)+4)
+	for _, column := range table.Columns {
+		columnNames = append(columnNames, column.Name)
+	}
+	columnNames = append(columnNames, "longitude", "latitude", "osm_type", "osm_id")
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columnNames)), ",") + ")"
+
+	for i := 0; i < len(records); i += maxRecordsPerBatch {
+		end := i + maxRecordsPerBatch
+		if end > len(records) {
+			end = len(records)
+		}
+		currentBatch := records[i:end]
+
+		query := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES ", table.Name, strings.Join(columnNames, ", "))
+		args := make([]interface{}, 0, len(currentBatch)*len(columnNames))
+
+		for j, rec := range currentBatch {
+			if j > 0 {
+				query += ","
+			}
+			query += placeholders
+
+			for _, column := range table.Columns {
+				args = append(args, rec.Values[column.Name])
+			}
+			args = append(args, rec.Lon, rec.Lat, rec.OSMType, rec.OSMID)
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
 			return err
 		}
 	}
 
-	// ANALYZE für den Query Optimizer
-	_, err = db.Exec("ANALYZE")
-	if err != nil {
-		return err
+	return nil
+}
+
+// finalizeMappedTables erstellt nach dem Bulk-Import je Tabelle Indizes und
+// (falls konfiguriert) eine FTS5-Volltextsuche. Für "addresses" wird
+// zusätzlich der R*Tree-Raumindex für die Umkreissuche angelegt.
+func finalizeMappedTables(db *sql.DB, mapping *MappingConfig) error {
+	fmt.Println("📊 Erstelle Indizes...")
+
+	for _, table := range mapping.Tables {
+		if table.Name == "addresses" {
+			createIndices := []string{
+				"CREATE INDEX idx_city ON addresses(city)",
+				"CREATE INDEX idx_street ON addresses(street)",
+				"CREATE INDEX idx_street_house ON addresses(street, house_number)",
+			}
+			for _, indexSQL := range createIndices {
+				if _, err := db.Exec(indexSQL); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
-	// Optimieren
-	_, err = db.Exec("PRAGMA optimize")
-	if err != nil {
+	if _, err := db.Exec("ANALYZE"); err != nil {
+		return err
+	}
+	if _, err := db.Exec("PRAGMA optimize"); err != nil {
 		return err
 	}
 
-	// Datenbank komprimieren nach dem Import
 	fmt.Println("📊 Komprimiere Datenbank (VACUUM)...")
-	_, err = db.Exec("VACUUM")
-	if err != nil {
+	if _, err := db.Exec("VACUUM"); err != nil {
 		return err
 	}
 
-	// FTS5-Virtualtabelle erstellen
-	fmt.Println("🔍 Erstelle FTS5-Volltextsuchindex...")
-	createFTSTableSQL := `
-	CREATE VIRTUAL TABLE address_fts USING fts5(
-        street, 
-        house_number, 
-        city, 
-        content='addresses', 
-        content_rowid='id',
-        tokenize="unicode61 remove_diacritics 0 tokenchars '\x2d'"
-	);
-	`
-	_, err = db.Exec(createFTSTableSQL)
-	if err != nil {
-		return fmt.Errorf("fehler beim erstellen der FTS5-Tabelle: %w", err)
-	}
+	for _, table := range mapping.Tables {
+		if len(table.FTSColumns) == 0 {
+			continue
+		}
 
-	// FTS5-Index mit Daten befüllen
-	fmt.Println("🔄 Befülle FTS5-Index mit Daten...")
-	_, err = db.Exec("INSERT INTO address_fts(rowid, street, house_number, city) SELECT id, street, house_number, city FROM addresses")
-	if err != nil {
-		return fmt.Errorf("fehler beim befüllen des FTS5-Index: %w", err)
+		ftsName := table.Name + "_fts"
+		fmt.Printf("🔍 Erstelle FTS5-Volltextsuchindex für %s...\n", table.Name)
+
+		columnList := strings.Join(table.FTSColumns, ", ")
+		createFTSTableSQL := fmt.Sprintf(`
+		CREATE VIRTUAL TABLE %s USING fts5(
+			%s,
+			content='%s',
+			content_rowid='id',
+			tokenize="unicode61 remove_diacritics 0 tokenchars '\x2d'"
+		);
+		`, ftsName, columnList, table.Name)
+		if _, err := db.Exec(createFTSTableSQL); err != nil {
+			return fmt.Errorf("fehler beim erstellen der FTS5-tabelle %q: %w", ftsName, err)
+		}
+
+		insertFTSSQL := fmt.Sprintf(
+			"INSERT INTO %s(rowid, %s) SELECT id, %s FROM %s",
+			ftsName, columnList, columnList, table.Name,
+		)
+		if _, err := db.Exec(insertFTSSQL); err != nil {
+			return fmt.Errorf("fehler beim befüllen des FTS5-index %q: %w", ftsName, err)
+		}
+
+		fmt.Printf("✅ FTS5-Volltextsuchindex %s wurde erfolgreich erstellt.\n", ftsName)
 	}
 
-	fmt.Println("✅ FTS5-Volltextsuchindex wurde erfolgreich erstellt.")
+	for _, table := range mapping.Tables {
+		if table.Name != "addresses" {
+			continue
+		}
 
-	// Statistiken ausgeben
-	totalTime := time.Since(startTime).Seconds()
-	fmt.Printf("✅ Fertig. %d Adressen wurden in %.1f Sekunden importiert (%.1f Einträge/Sek).\n",
-		totalCount,
-		totalTime,
-		float64(totalCount)/totalTime,
-	)
+		fmt.Println("🌐 Erstelle R*Tree-Raumindex...")
+		createRTreeTableSQL := `
+		CREATE VIRTUAL TABLE addresses_rtree USING rtree(
+			id,
+			min_lon, max_lon,
+			min_lat, max_lat
+		);
+		`
+		if _, err := db.Exec(createRTreeTableSQL); err != nil {
+			return fmt.Errorf("fehler beim erstellen des R*Tree-index: %w", err)
+		}
+
+		_, err := db.Exec(`
+			INSERT INTO addresses_rtree(id, min_lon, max_lon, min_lat, max_lat)
+			SELECT id, longitude, longitude, latitude, latitude FROM addresses
+		`)
+		if err != nil {
+			return fmt.Errorf("fehler beim befüllen des R*Tree-index: %w", err)
+		}
+
+		fmt.Println("✅ R*Tree-Raumindex wurde erfolgreich erstellt.")
+	}
 
 	return nil
 }
@@ -470,15 +572,15 @@ func bulkInsert(tx *sql.Tx, records []*AddressRecord) error {
 		currentBatch := records[i:end]
 
 		// Baue SQL für Multi-Value Insert
-		query := "INSERT OR IGNORE INTO addresses (street, house_number, city, longitude, latitude) VALUES "
-		args := make([]interface{}, 0, len(currentBatch)*5)
+		query := "INSERT OR IGNORE INTO addresses (street, house_number, city, longitude, latitude, osm_type, osm_id) VALUES "
+		args := make([]interface{}, 0, len(currentBatch)*7)
 
 		for j, rec := range currentBatch {
 			if j > 0 {
 				query += ","
 			}
-			query += "(?, ?, ?, ?, ?)"
-			args = append(args, rec.Street, rec.HouseNumber, rec.City, rec.Lon, rec.Lat)
+			query += "(?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, rec.Street, rec.HouseNumber, rec.City, rec.Lon, rec.Lat, rec.OSMType, rec.OSMID)
 		}
 
 		_, err := tx.Exec(query, args...)
@@ -515,45 +617,277 @@ func extractAddressData(feature *Feature) (*AddressRecord, bool) {
 
 	// Koordinaten extrahieren
 	var lon, lat float64
+	var ok bool
 
 	switch feature.Geometry.Type {
 	case "Point":
 		var coords []float64
-		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil && len(coords) >= 2 {
-			lon, lat = coords[0], coords[1]
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil {
+			lon, lat, ok = pointFromPoint(coords)
 		}
 	case "LineString":
 		var coords [][]float64
-		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil && len(coords) > 0 && len(coords[0]) >= 2 {
-			lon, lat = coords[0][0], coords[0][1]
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil {
+			lon, lat, ok = pointFromLineString(coords)
 		}
 	case "Polygon":
 		var coords [][][]float64
-		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil && len(coords) > 0 && len(coords[0]) > 0 && len(coords[0][0]) >= 2 {
-			lon, lat = coords[0][0][0], coords[0][0][1]
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil {
+			lon, lat, ok = pointFromPolygon(coords)
 		}
 	case "MultiPolygon":
 		var coords [][][][]float64
-		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil && len(coords) > 0 && len(coords[0]) > 0 && len(coords[0][0]) > 0 && len(coords[0][0][0]) >= 2 {
-			lon, lat = coords[0][0][0][0], coords[0][0][0][1]
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil {
+			lon, lat, ok = pointFromMultiPolygon(coords)
 		}
 	default:
 		return nil, false
 	}
 
-	if lon == 0 && lat == 0 {
+	if !ok || (lon == 0 && lat == 0) {
 		return nil, false
 	}
 
+	osmType, osmID := parseOSMID(properties)
+
 	return &AddressRecord{
 		Street:      fmt.Sprintf("%v", street),
 		HouseNumber: fmt.Sprintf("%v", houseNumber),
 		City:        fmt.Sprintf("%v", city),
 		Lon:         lon,
 		Lat:         lat,
+		OSMType:     osmType,
+		OSMID:       osmID,
 	}, true
 }
 
+// pointFromPoint liefert die Koordinate eines GeoJSON-Point
+func pointFromPoint(coords []float64) (lon, lat float64, ok bool) {
+	if len(coords) < 2 {
+		return 0, 0, false
+	}
+	return coords[0], coords[1], true
+}
+
+// pointFromLineString liefert den Referenzpunkt eines LineString: den
+// Mittelpunkt entlang der kumulierten Bogenlänge, statt nur den ersten
+// Stützpunkt
+func pointFromLineString(coords [][]float64) (lon, lat float64, ok bool) {
+	if len(coords) == 0 || len(coords[0]) < 2 {
+		return 0, 0, false
+	}
+	if len(coords) == 1 {
+		return coords[0][0], coords[0][1], true
+	}
+
+	segmentLengths := make([]float64, len(coords)-1)
+	totalLength := 0.0
+	for i := 0; i < len(coords)-1; i++ {
+		dx := coords[i+1][0] - coords[i][0]
+		dy := coords[i+1][1] - coords[i][1]
+		segmentLengths[i] = math.Hypot(dx, dy)
+		totalLength += segmentLengths[i]
+	}
+
+	if totalLength == 0 {
+		return coords[0][0], coords[0][1], true
+	}
+
+	halfLength := totalLength / 2
+	traveled := 0.0
+	for i, segLen := range segmentLengths {
+		if traveled+segLen >= halfLength {
+			remaining := halfLength - traveled
+			fraction := remaining / segLen
+			x := coords[i][0] + fraction*(coords[i+1][0]-coords[i][0])
+			y := coords[i][1] + fraction*(coords[i+1][1]-coords[i][1])
+			return x, y, true
+		}
+		traveled += segLen
+	}
+
+	last := coords[len(coords)-1]
+	return last[0], last[1], true
+}
+
+// pointFromPolygon liefert den Referenzpunkt eines Polygon: den
+// flächengewichteten Schwerpunkt (Shoelace-Formel) des äußeren Rings, mit
+// Fallback auf das Bounding-Box-Zentrum bei Fläche 0 und auf
+// pointOnSurface, falls der Schwerpunkt außerhalb des (ggf. konkaven)
+// Polygons liegt
+func pointFromPolygon(coords [][][]float64) (lon, lat float64, ok bool) {
+	if len(coords) == 0 || len(coords[0]) == 0 {
+		return 0, 0, false
+	}
+
+	ring := coords[0]
+	if len(ring) == 0 || len(ring[0]) < 2 {
+		return 0, 0, false
+	}
+
+	cx, cy, area := ringCentroid(ring)
+	if area == 0 {
+		cx, cy = ringBoundingBoxCenter(ring)
+	} else if !pointInRing(cx, cy, ring) {
+		cx, cy = pointOnSurface(ring, cy)
+	}
+
+	return cx, cy, true
+}
+
+// pointFromMultiPolygon liefert den Referenzpunkt eines MultiPolygon: den
+// Schwerpunkt des flächenmäßig größten Teilpolygons
+func pointFromMultiPolygon(coords [][][][]float64) (lon, lat float64, ok bool) {
+	if len(coords) == 0 {
+		return 0, 0, false
+	}
+
+	largestIdx := -1
+	largestArea := -1.0
+
+	for i, polygon := range coords {
+		if len(polygon) == 0 {
+			continue
+		}
+		_, _, area := ringCentroid(polygon[0])
+		if absArea := math.Abs(area); absArea > largestArea {
+			largestArea = absArea
+			largestIdx = i
+		}
+	}
+
+	if largestIdx == -1 {
+		return 0, 0, false
+	}
+
+	return pointFromPolygon(coords[largestIdx])
+}
+
+// ringCentroid berechnet den flächengewichteten Schwerpunkt eines Rings nach
+// der Shoelace-Formel: Cx = Σ((xi+xi+1)(xi*yi+1 - xi+1*yi)) / (6A), analog
+// für Cy. area ist vorzeichenbehaftet (negativ bei im Uhrzeigersinn
+// orientierten Ringen).
+func ringCentroid(ring [][]float64) (cx, cy, area float64) {
+	n := len(ring)
+	if n < 3 {
+		return 0, 0, 0
+	}
+
+	var signedArea, sumX, sumY float64
+	for i := 0; i < n; i++ {
+		x0, y0 := ring[i][0], ring[i][1]
+		x1, y1 := ring[(i+1)%n][0], ring[(i+1)%n][1]
+
+		cross := x0*y1 - x1*y0
+		signedArea += cross
+		sumX += (x0 + x1) * cross
+		sumY += (y0 + y1) * cross
+	}
+
+	signedArea /= 2
+	if signedArea == 0 {
+		return 0, 0, 0
+	}
+
+	cx = sumX / (6 * signedArea)
+	cy = sumY / (6 * signedArea)
+	return cx, cy, signedArea
+}
+
+// ringBoundingBoxCenter liefert das Zentrum der Bounding-Box eines Rings
+func ringBoundingBoxCenter(ring [][]float64) (cx, cy float64) {
+	minX, minY := ring[0][0], ring[0][1]
+	maxX, maxY := ring[0][0], ring[0][1]
+
+	for _, p := range ring {
+		minX = math.Min(minX, p[0])
+		maxX = math.Max(maxX, p[0])
+		minY = math.Min(minY, p[1])
+		maxY = math.Max(maxY, p[1])
+	}
+
+	return (minX + maxX) / 2, (minY + maxY) / 2
+}
+
+// pointInRing prüft per Ray-Casting, ob (x, y) innerhalb des Rings liegt
+func pointInRing(x, y float64, ring [][]float64) bool {
+	inside := false
+	n := len(ring)
+
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		intersects := (yi > y) != (yj > y) &&
+			x < (xj-xi)*(y-yi)/(yj-yi)+xi
+		if intersects {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// pointOnSurface liefert einen garantiert innerhalb des Rings liegenden
+// Punkt, analog zu PostGIS ST_PointOnSurface: ein horizontaler Scanline bei
+// y, der Mittelpunkt des längsten inneren Segments wird zurückgegeben
+func pointOnSurface(ring [][]float64, y float64) (x, resultY float64) {
+	n := len(ring)
+
+	var intersections []float64
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		if (yi > y) == (yj > y) {
+			continue
+		}
+
+		ix := (xj-xi)*(y-yi)/(yj-yi) + xi
+		intersections = append(intersections, ix)
+	}
+
+	if len(intersections) < 2 {
+		cx, cy := ringBoundingBoxCenter(ring)
+		return cx, cy
+	}
+
+	sort.Float64s(intersections)
+
+	longestStart, longestLen := intersections[0], 0.0
+	for i := 0; i+1 < len(intersections); i += 2 {
+		segLen := intersections[i+1] - intersections[i]
+		if segLen > longestLen {
+			longestLen = segLen
+			longestStart = intersections[i]
+		}
+	}
+
+	return longestStart + longestLen/2, y
+}
+
+// parseOSMID liest den von "osmium export" gesetzten "@id"-Property-Wert
+// (Format "n<id>", "w<id>" oder "r<id>") und liefert Typkürzel und ID getrennt.
+func parseOSMID(properties map[string]interface{}) (string, int64) {
+	raw, ok := properties["@id"]
+	if !ok {
+		return "", 0
+	}
+
+	idStr := fmt.Sprintf("%v", raw)
+	if idStr == "" {
+		return "", 0
+	}
+
+	osmType := idStr[:1]
+	id, err := strconv.ParseInt(idStr[1:], 10, 64)
+	if err != nil {
+		return "", 0
+	}
+
+	return osmType, id
+}
+
 func searchAddresses() error {
 	fmt.Println("🔍 FTS5-Volltextsuche Demo")
 
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRingCentroidSquare(t *testing.T) {
+	// Einheitsquadrat (0,0)-(2,0)-(2,2)-(0,2), Schwerpunkt bei (1,1)
+	ring := [][]float64{{0, 0}, {2, 0}, {2, 2}, {0, 2}}
+
+	cx, cy, area := ringCentroid(ring)
+
+	if math.Abs(cx-1) > 1e-9 || math.Abs(cy-1) > 1e-9 {
+		t.Fatalf("centroid = (%v, %v), want (1, 1)", cx, cy)
+	}
+	if math.Abs(math.Abs(area)-4) > 1e-9 {
+		t.Fatalf("area = %v, want ±4", area)
+	}
+}
+
+func TestRingCentroidDegenerate(t *testing.T) {
+	// Weniger als 3 Punkte ergeben keine Fläche
+	cx, cy, area := ringCentroid([][]float64{{0, 0}, {1, 1}})
+	if cx != 0 || cy != 0 || area != 0 {
+		t.Fatalf("ringCentroid(<3 points) = (%v, %v, %v), want (0, 0, 0)", cx, cy, area)
+	}
+}
+
+func TestPointInRing(t *testing.T) {
+	square := [][]float64{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+
+	tests := []struct {
+		name string
+		x, y float64
+		want bool
+	}{
+		{"center", 2, 2, true},
+		{"outside", 5, 5, false},
+		{"just outside left", -0.1, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pointInRing(tt.x, tt.y, square); got != tt.want {
+				t.Errorf("pointInRing(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPointOnSurfaceConcaveRing(t *testing.T) {
+	// C-förmiger (konkaver) Ring: der flächengewichtete Schwerpunkt läge in
+	// der Aussparung, außerhalb des Rings. pointOnSurface muss stattdessen
+	// einen garantiert innerhalb liegenden Punkt liefern.
+	ring := [][]float64{
+		{0, 0}, {6, 0}, {6, 2}, {2, 2}, {2, 4}, {6, 4}, {6, 6}, {0, 6},
+	}
+
+	cx, cy, area := ringCentroid(ring)
+	if pointInRing(cx, cy, ring) {
+		t.Fatalf("test setup invalid: centroid (%v, %v) already lies inside the concave ring", cx, cy)
+	}
+
+	x, y := pointOnSurface(ring, cy)
+	if math.Abs(area) == 0 {
+		t.Fatalf("test setup invalid: ring has zero area")
+	}
+	if !pointInRing(x, y, ring) {
+		t.Fatalf("pointOnSurface(ring, %v) = (%v, %v), which does not lie inside the ring", cy, x, y)
+	}
+}
+
+func TestParseOSMID(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]interface{}
+		wantType   string
+		wantID     int64
+	}{
+		{"node", map[string]interface{}{"@id": "n123"}, "n", 123},
+		{"way", map[string]interface{}{"@id": "w456"}, "w", 456},
+		{"relation", map[string]interface{}{"@id": "r789"}, "r", 789},
+		{"missing", map[string]interface{}{}, "", 0},
+		{"malformed", map[string]interface{}{"@id": "nabc"}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			osmType, id := parseOSMID(tt.properties)
+			if osmType != tt.wantType || id != tt.wantID {
+				t.Errorf("parseOSMID(%v) = (%q, %d), want (%q, %d)", tt.properties, osmType, id, tt.wantType, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MappingConfig beschreibt, welche OSM-Objekte in welche SQLite-Tabellen
+// importiert werden, angelehnt an imposm3-Mapping-Dateien. Damit wird die
+// Extraktion nicht mehr auf Gebäude mit addr:street beschränkt, sondern
+// erlaubt beliebige weitere Tabellen (z.B. POIs, Highways).
+type MappingConfig struct {
+	Tables []TableMapping `yaml:"tables" json:"tables"`
+}
+
+// TableMapping beschreibt eine Zieltabelle: welche Tags sie selektiert,
+// welche Geometrietypen sie akzeptiert und welche Spalten (inkl. FTS5-Index)
+// sie befüllt.
+type TableMapping struct {
+	Name          string          `yaml:"name" json:"name"`
+	GeometryTypes []string        `yaml:"geometry_types" json:"geometry_types"`
+	Selectors     []TagSelector   `yaml:"selectors" json:"selectors"`
+	Columns       []ColumnMapping `yaml:"columns" json:"columns"`
+	FTSColumns    []string        `yaml:"fts_columns" json:"fts_columns"`
+}
+
+// TagSelector matcht ein OSM-Objekt, wenn der Tag Key vorhanden ist und
+// (falls Values gesetzt ist) sein Wert darin enthalten ist, z.B.
+// "amenity in (restaurant, cafe, pharmacy)"
+type TagSelector struct {
+	Key    string   `yaml:"key" json:"key"`
+	Values []string `yaml:"values" json:"values"`
+}
+
+// ColumnMapping ordnet einer Tabellenspalte einen OSM-Tag zu
+type ColumnMapping struct {
+	Name string `yaml:"name" json:"name"`
+	Tag  string `yaml:"tag" json:"tag"`
+	Type string `yaml:"type" json:"type"`
+}
+
+// defaultMapping bildet das bisherige, fest verdrahtete addresses-Schema als
+// Mapping-Konfiguration ab und wird verwendet, solange kein -mapping
+// übergeben wird.
+func defaultMapping() *MappingConfig {
+	return &MappingConfig{
+		Tables: []TableMapping{
+			{
+				Name:          "addresses",
+				GeometryTypes: []string{"point", "linestring", "polygon", "multipolygon"},
+				Selectors:     []TagSelector{{Key: "addr:street"}},
+				Columns: []ColumnMapping{
+					{Name: "street", Tag: "addr:street", Type: "TEXT"},
+					{Name: "house_number", Tag: "addr:housenumber", Type: "TEXT"},
+					{Name: "city", Tag: "addr:city", Type: "TEXT"},
+				},
+				FTSColumns: []string{"street", "house_number", "city"},
+			},
+		},
+	}
+}
+
+// loadMappingConfig lädt eine YAML- oder JSON-Mapping-Datei, je nach
+// Dateiendung
+func loadMappingConfig(path string) (*MappingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config MappingConfig
+	if strings.HasSuffix(path, ".json") {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("fehler beim parsen der mapping-datei (json): %w", err)
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("fehler beim parsen der mapping-datei (yaml): %w", err)
+		}
+	}
+
+	if len(config.Tables) == 0 {
+		return nil, fmt.Errorf("fehler: mapping-datei %q enthält keine tabellen", path)
+	}
+
+	for _, table := range config.Tables {
+		if table.Name == "" {
+			return nil, fmt.Errorf("fehler: mapping-datei %q enthält eine tabelle ohne name", path)
+		}
+		if len(table.Selectors) == 0 {
+			return nil, fmt.Errorf("fehler: tabelle %q hat keine selektoren", table.Name)
+		}
+	}
+
+	return &config, nil
+}
+
+// tableByName liefert die Tabellenkonfiguration mit dem gegebenen Namen
+func (m *MappingConfig) tableByName(name string) TableMapping {
+	for _, table := range m.Tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	return TableMapping{Name: name}
+}
+
+// hasTable meldet, ob das Mapping eine Tabelle mit dem gegebenen Namen
+// definiert
+func (m *MappingConfig) hasTable(name string) bool {
+	for _, table := range m.Tables {
+		if table.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matches prüft, ob die gegebenen Tags mindestens einen Selektor dieser
+// Tabelle erfüllen
+func (t TableMapping) matches(tags map[string]string) bool {
+	for _, selector := range t.Selectors {
+		value, present := tags[selector.Key]
+		if !present {
+			continue
+		}
+		if len(selector.Values) == 0 {
+			return true
+		}
+		for _, allowed := range selector.Values {
+			if value == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptsGeometry prüft, ob diese Tabelle den gegebenen Geometrietyp
+// ("point", "linestring", "polygon" oder "multipolygon") akzeptiert. Fehlt
+// GeometryTypes in der Mapping-Datei, werden alle Geometrietypen akzeptiert.
+func (t TableMapping) acceptsGeometry(kind string) bool {
+	if len(t.GeometryTypes) == 0 {
+		return true
+	}
+	for _, allowed := range t.GeometryTypes {
+		if allowed == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// columnValue liefert den Wert einer Spalte aus den Tags eines OSM-Objekts.
+// addr:city erhält dabei dieselbe Fallback-Kette (addr:town, addr:village)
+// wie der Rest der Adress-Logik.
+func columnValue(tags map[string]string, column ColumnMapping) string {
+	if column.Tag == "addr:city" {
+		return addrCity(tags)
+	}
+	return tags[column.Tag]
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTableMappingMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		table TableMapping
+		tags  map[string]string
+		want  bool
+	}{
+		{
+			name:  "key ohne values matcht jeden wert",
+			table: TableMapping{Selectors: []TagSelector{{Key: "addr:street"}}},
+			tags:  map[string]string{"addr:street": "Hauptstraße"},
+			want:  true,
+		},
+		{
+			name:  "key fehlt",
+			table: TableMapping{Selectors: []TagSelector{{Key: "addr:street"}}},
+			tags:  map[string]string{"amenity": "cafe"},
+			want:  false,
+		},
+		{
+			name:  "values eingeschränkt, wert erlaubt",
+			table: TableMapping{Selectors: []TagSelector{{Key: "amenity", Values: []string{"restaurant", "cafe"}}}},
+			tags:  map[string]string{"amenity": "cafe"},
+			want:  true,
+		},
+		{
+			name:  "values eingeschränkt, wert nicht erlaubt",
+			table: TableMapping{Selectors: []TagSelector{{Key: "amenity", Values: []string{"restaurant", "cafe"}}}},
+			tags:  map[string]string{"amenity": "fuel"},
+			want:  false,
+		},
+		{
+			name: "mehrere selektoren, zweiter matcht",
+			table: TableMapping{Selectors: []TagSelector{
+				{Key: "addr:street"},
+				{Key: "highway", Values: []string{"residential"}},
+			}},
+			tags: map[string]string{"highway": "residential"},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.table.matches(tt.tags); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableMappingAcceptsGeometry(t *testing.T) {
+	tests := []struct {
+		name  string
+		table TableMapping
+		kind  string
+		want  bool
+	}{
+		{"keine geometry_types erlaubt alles", TableMapping{}, "polygon", true},
+		{"geometrietyp erlaubt", TableMapping{GeometryTypes: []string{"point", "polygon"}}, "polygon", true},
+		{"geometrietyp nicht erlaubt", TableMapping{GeometryTypes: []string{"point", "polygon"}}, "linestring", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.table.acceptsGeometry(tt.kind); got != tt.want {
+				t.Errorf("acceptsGeometry(%q) = %v, want %v", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   map[string]string
+		column ColumnMapping
+		want   string
+	}{
+		{
+			name:   "einfacher tag",
+			tags:   map[string]string{"addr:street": "Hauptstraße"},
+			column: ColumnMapping{Name: "street", Tag: "addr:street"},
+			want:   "Hauptstraße",
+		},
+		{
+			name:   "fehlender tag ergibt leeren string",
+			tags:   map[string]string{},
+			column: ColumnMapping{Name: "street", Tag: "addr:street"},
+			want:   "",
+		},
+		{
+			name:   "addr:city nutzt die gleiche fallback-kette wie addrCity",
+			tags:   map[string]string{"addr:town": "Erlangen"},
+			column: ColumnMapping{Name: "city", Tag: "addr:city"},
+			want:   "Erlangen",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := columnValue(tt.tags, tt.column); got != tt.want {
+				t.Errorf("columnValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMappingConfigValid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mapping.yml")
+	writeFile(t, path, `
+tables:
+  - name: pois
+    geometry_types: [point]
+    selectors:
+      - key: amenity
+        values: [restaurant, cafe]
+    columns:
+      - name: amenity
+        tag: amenity
+        type: TEXT
+    fts_columns: [amenity]
+`)
+
+	config, err := loadMappingConfig(path)
+	if err != nil {
+		t.Fatalf("loadMappingConfig: %v", err)
+	}
+	if len(config.Tables) != 1 || config.Tables[0].Name != "pois" {
+		t.Fatalf("config = %+v, want eine tabelle \"pois\"", config)
+	}
+}
+
+func TestLoadMappingConfigRejectsNoTables(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mapping.yml")
+	writeFile(t, path, "tables: []\n")
+
+	if _, err := loadMappingConfig(path); err == nil {
+		t.Fatal("loadMappingConfig mit leerer tables-liste hätte einen fehler liefern müssen")
+	}
+}
+
+func TestLoadMappingConfigRejectsMissingName(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mapping.yml")
+	writeFile(t, path, `
+tables:
+  - selectors:
+      - key: amenity
+`)
+
+	if _, err := loadMappingConfig(path); err == nil {
+		t.Fatal("loadMappingConfig ohne tabellenname hätte einen fehler liefern müssen")
+	}
+}
+
+func TestLoadMappingConfigRejectsMissingSelectors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mapping.yml")
+	writeFile(t, path, `
+tables:
+  - name: pois
+`)
+
+	if _, err := loadMappingConfig(path); err == nil {
+		t.Fatal("loadMappingConfig ohne selektoren hätte einen fehler liefern müssen")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("schreiben von %q: %v", path, err)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"runtime"
+
+	"github.com/paulmach/osm"
+	"github.com/paulmach/osm/osmpbf"
+	bolt "go.etcd.io/bbolt"
+)
+
+const nodeLocationBucket = "node_locations"
+const wayRingBucket = "way_rings"
+
+// mappedRecord ist ein einzelner, einer Zieltabelle zugeordneter Datensatz,
+// wie er von scanPBFMapped an den Aufrufer übergeben wird
+type mappedRecord struct {
+	Values  map[string]string
+	Lon     float64
+	Lat     float64
+	OSMType string
+	OSMID   int64
+}
+
+// scanPBFMapped liest eine PBF-Datei direkt (ohne osmium und ohne
+// GeoJSON-Zwischendatei) in zwei Durchgängen ein: im ersten Durchgang werden
+// alle Knotenkoordinaten in einem on-disk Key-Value-Cache abgelegt, damit der
+// Speicherbedarf unabhängig von der Dateigröße bleibt. Im zweiten Durchgang
+// wird für jedes Element geprüft, welche Tabellen des Mapping es selektieren,
+// sein Referenzpunkt aufgelöst und onRecord je Treffer aufgerufen.
+func scanPBFMapped(pbfFile string, mapping *MappingConfig, onRecord func(table TableMapping, rec mappedRecord) error) error {
+	cachePath := pbfFile + ".nodecache.db"
+	defer os.Remove(cachePath)
+
+	cache, err := bolt.Open(cachePath, 0600, nil)
+	if err != nil {
+		return fmt.Errorf("fehler beim anlegen des node-caches: %w", err)
+	}
+	defer cache.Close()
+
+	if err := cache.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte(nodeLocationBucket)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists([]byte(wayRingBucket))
+		return err
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println("🔍 Durchlauf 1/2: Knotenkoordinaten und Way-Ringe zwischenspeichern...")
+	if err := cacheNodeLocations(pbfFile, cache); err != nil {
+		return fmt.Errorf("fehler beim zwischenspeichern der knoten: %w", err)
+	}
+
+	fmt.Println("🛠 Durchlauf 2/2: Tabellen gemäß Mapping befüllen...")
+	return resolveMappedRecords(pbfFile, cache, mapping, onRecord)
+}
+
+// cacheNodeLocations durchläuft die PBF-Datei einmal und schreibt für jeden
+// Knoten seine Koordinate in den bolt-Cache. Da Ways in der PBF-Datei nach
+// ihren Knoten, aber vor den Relationen folgen, wird hier zusätzlich für
+// jeden Way sein Koordinaten-Ring aus den bereits im selben Durchlauf
+// zwischengespeicherten Knoten aufgelöst und unter seiner Way-ID abgelegt.
+// Das macht Way-Ringe im zweiten Durchgang auch über Relation-Member
+// (die nur die Way-ID, nicht das Way-Objekt liefern) auflösbar.
+func cacheNodeLocations(pbfFile string, cache *bolt.DB) error {
+	f, err := os.Open(pbfFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := osmpbf.New(context.Background(), f, runtime.GOMAXPROCS(-1))
+	defer scanner.Close()
+
+	const flushEvery = 100000
+	pending := 0
+
+	tx, err := cache.Begin(true)
+	if err != nil {
+		return err
+	}
+	nodeBucket := tx.Bucket([]byte(nodeLocationBucket))
+	wayBucket := tx.Bucket([]byte(wayRingBucket))
+
+	for scanner.Scan() {
+		switch o := scanner.Object().(type) {
+		case *osm.Node:
+			if err := nodeBucket.Put(encodeID(int64(o.ID)), encodeLatLon(o.Lat, o.Lon)); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+		case *osm.Way:
+			ring := make([][]float64, 0, len(o.Nodes))
+			for _, wayNode := range o.Nodes {
+				value := nodeBucket.Get(encodeID(int64(wayNode.ID)))
+				if value == nil {
+					continue
+				}
+				lat, lon := decodeLatLon(value)
+				ring = append(ring, []float64{lon, lat})
+			}
+			if len(ring) > 0 {
+				if err := wayBucket.Put(encodeID(int64(o.ID)), encodeRing(ring)); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
+		default:
+			continue
+		}
+
+		pending++
+		if pending >= flushEvery {
+			if err := tx.Commit(); err != nil {
+				return err
+			}
+			tx, err = cache.Begin(true)
+			if err != nil {
+				return err
+			}
+			nodeBucket = tx.Bucket([]byte(nodeLocationBucket))
+			wayBucket = tx.Bucket([]byte(wayRingBucket))
+			pending = 0
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return scanner.Err()
+}
+
+// resolveMappedRecords durchläuft die PBF-Datei ein zweites Mal. Für jedes
+// Element werden die Tags einmal gelesen, der Referenzpunkt (abhängig vom
+// Geometrietyp) einmal aufgelöst, und anschließend an jede Tabelle übergeben,
+// deren Selektoren auf das Element zutreffen.
+func resolveMappedRecords(pbfFile string, cache *bolt.DB, mapping *MappingConfig, onRecord func(table TableMapping, rec mappedRecord) error) error {
+	f, err := os.Open(pbfFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := osmpbf.New(context.Background(), f, runtime.GOMAXPROCS(-1))
+	defer scanner.Close()
+
+	for scanner.Scan() {
+		var tags map[string]string
+		var osmType string
+		var osmID int64
+		var lon, lat float64
+		var geomKind string
+		var ok bool
+
+		switch o := scanner.Object().(type) {
+		case *osm.Node:
+			tags, osmType, osmID = tagsToMap(o.Tags), "n", int64(o.ID)
+			lon, lat, geomKind, ok = o.Lon, o.Lat, "point", true
+
+		case *osm.Way:
+			tags, osmType, osmID = tagsToMap(o.Tags), "w", int64(o.ID)
+			lon, lat, geomKind, ok = wayRepresentativePoint(o, cache)
+
+		case *osm.Relation:
+			tags, osmType, osmID = tagsToMap(o.Tags), "r", int64(o.ID)
+			lon, lat, ok = relationRepresentativePoint(o, cache)
+			geomKind = "multipolygon"
+
+		default:
+			continue
+		}
+
+		if !ok || (lon == 0 && lat == 0) {
+			continue
+		}
+
+		for _, table := range mapping.Tables {
+			if !table.acceptsGeometry(geomKind) || !table.matches(tags) {
+				continue
+			}
+
+			values := make(map[string]string, len(table.Columns))
+			for _, column := range table.Columns {
+				values[column.Name] = columnValue(tags, column)
+			}
+
+			rec := mappedRecord{Values: values, Lon: lon, Lat: lat, OSMType: osmType, OSMID: osmID}
+			if err := onRecord(table, rec); err != nil {
+				return err
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// wayRepresentativePoint löst den Referenzpunkt eines Way auf: für
+// geschlossene Ways (Gebäudeumrisse) den Polygon-Mittelpunkt, ansonsten den
+// ersten Stützpunkt. Der zurückgegebene Geometrietyp ("polygon" bzw.
+// "linestring") wird gegen TableMapping.GeometryTypes geprüft.
+func wayRepresentativePoint(way *osm.Way, cache *bolt.DB) (lon, lat float64, kind string, ok bool) {
+	ring := wayRing(way, cache)
+	if len(ring) == 0 {
+		return 0, 0, "", false
+	}
+
+	if len(ring) >= 4 && ring[0][0] == ring[len(ring)-1][0] && ring[0][1] == ring[len(ring)-1][1] {
+		lon, lat, ok = pointFromPolygon([][][]float64{ring})
+		return lon, lat, "polygon", ok
+	}
+
+	lon, lat, ok = pointFromLineString(ring)
+	return lon, lat, "linestring", ok
+}
+
+// relationRepresentativePoint löst den Referenzpunkt einer Relation
+// (typischerweise ein Multipolygon) über die äußeren Ways ihrer Member auf
+func relationRepresentativePoint(relation *osm.Relation, cache *bolt.DB) (lon, lat float64, ok bool) {
+	var polygons [][][][]float64
+
+	for _, member := range relation.Members {
+		if member.Type != osm.TypeWay || (member.Role != "outer" && member.Role != "") {
+			continue
+		}
+
+		ring, found := wayRingByID(osm.WayID(member.Ref), cache)
+		if !found {
+			continue
+		}
+		polygons = append(polygons, [][][]float64{ring})
+	}
+
+	if len(polygons) == 0 {
+		log.Printf("⚠️  relation %d: kein outer-way im ring-cache gefunden, überspringe", relation.ID)
+		return 0, 0, false
+	}
+
+	return pointFromMultiPolygon(polygons)
+}
+
+// wayRing löst die Knoten eines Way über den Node-Cache zu einem Koordinaten-
+// Ring auf
+func wayRing(way *osm.Way, cache *bolt.DB) [][]float64 {
+	ring := make([][]float64, 0, len(way.Nodes))
+
+	_ = cache.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(nodeLocationBucket))
+		for _, wayNode := range way.Nodes {
+			value := bucket.Get(encodeID(int64(wayNode.ID)))
+			if value == nil {
+				continue
+			}
+			lat, lon := decodeLatLon(value)
+			ring = append(ring, []float64{lon, lat})
+		}
+		return nil
+	})
+
+	return ring
+}
+
+// wayRingByID ist wayRing für den Fall, dass (z.B. bei Relation-Membern) nur
+// die Way-ID, nicht aber das vollständige Way-Objekt vorliegt. Der Ring wird
+// aus dem im ersten Durchgang (cacheNodeLocations) befüllten Way-Ring-Cache
+// gelesen, statt erneut über die Way-Knoten aufgelöst zu werden.
+func wayRingByID(id osm.WayID, cache *bolt.DB) ([][]float64, bool) {
+	var ring [][]float64
+
+	_ = cache.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(wayRingBucket))
+		value := bucket.Get(encodeID(int64(id)))
+		if value == nil {
+			return nil
+		}
+		ring = decodeRing(value)
+		return nil
+	})
+
+	if len(ring) == 0 {
+		return nil, false
+	}
+	return ring, true
+}
+
+func tagsToMap(tags osm.Tags) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[t.Key] = t.Value
+	}
+	return m
+}
+
+// encodeID kodiert eine OSM-ID (Node- oder Way-ID) als big-endian
+// Bolt-Schlüssel
+func encodeID(id int64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(id))
+	return buf
+}
+
+// encodeRing kodiert einen Koordinaten-Ring als fortlaufende Folge von
+// lat/lon-Paaren für den Way-Ring-Cache
+func encodeRing(ring [][]float64) []byte {
+	buf := make([]byte, 0, 16*len(ring))
+	for _, point := range ring {
+		buf = append(buf, encodeLatLon(point[1], point[0])...)
+	}
+	return buf
+}
+
+// decodeRing ist die Umkehrung von encodeRing
+func decodeRing(data []byte) [][]float64 {
+	ring := make([][]float64, 0, len(data)/16)
+	for i := 0; i+16 <= len(data); i += 16 {
+		lat, lon := decodeLatLon(data[i : i+16])
+		ring = append(ring, []float64{lon, lat})
+	}
+	return ring
+}
+
+func encodeLatLon(lat, lon float64) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[0:8], math.Float64bits(lat))
+	binary.BigEndian.PutUint64(buf[8:16], math.Float64bits(lon))
+	return buf
+}
+
+func decodeLatLon(buf []byte) (lat, lon float64) {
+	lat = math.Float64frombits(binary.BigEndian.Uint64(buf[0:8]))
+	lon = math.Float64frombits(binary.BigEndian.Uint64(buf[8:16]))
+	return lat, lon
+}
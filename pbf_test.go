@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestEncodeDecodeIDRoundTrip(t *testing.T) {
+	ids := []int64{0, 1, 123456789, -42}
+
+	for _, id := range ids {
+		buf := encodeID(id)
+		if len(buf) != 8 {
+			t.Fatalf("encodeID(%d) returned %d bytes, want 8", id, len(buf))
+		}
+	}
+}
+
+func TestEncodeDecodeLatLonRoundTrip(t *testing.T) {
+	lat, lon := 49.4521, 11.0767
+
+	buf := encodeLatLon(lat, lon)
+	gotLat, gotLon := decodeLatLon(buf)
+
+	if gotLat != lat || gotLon != lon {
+		t.Fatalf("decodeLatLon(encodeLatLon(%v, %v)) = (%v, %v)", lat, lon, gotLat, gotLon)
+	}
+}
+
+func TestEncodeDecodeRingRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		ring [][]float64
+	}{
+		{"empty", [][]float64{}},
+		{"single point", [][]float64{{11.0767, 49.4521}}},
+		{"closed square", [][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeRing(encodeRing(tt.ring))
+
+			if len(got) != len(tt.ring) {
+				t.Fatalf("decodeRing(encodeRing(ring)) has %d points, want %d", len(got), len(tt.ring))
+			}
+			for i, point := range tt.ring {
+				if got[i][0] != point[0] || got[i][1] != point[1] {
+					t.Errorf("point %d = %v, want %v", i, got[i], point)
+				}
+			}
+		})
+	}
+}
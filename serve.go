@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// AddressSearchResult ist die JSON-Antwort eines einzelnen /search-Treffers
+type AddressSearchResult struct {
+	Street      string           `json:"street"`
+	HouseNumber string           `json:"house_number"`
+	City        string           `json:"city"`
+	Lon         float64          `json:"lon"`
+	Lat         float64          `json:"lat"`
+	Rank        float64          `json:"rank"`
+	Highlight   AddressHighlight `json:"highlight"`
+}
+
+// AddressHighlight enthält die FTS5-highlight()-Fragmente je Spalte
+type AddressHighlight struct {
+	Street      string `json:"street"`
+	HouseNumber string `json:"house_number"`
+	City        string `json:"city"`
+}
+
+// healthzResponse ist die JSON-Antwort von /healthz
+type healthzResponse struct {
+	RowCount   int64  `json:"row_count"`
+	LastUpdate string `json:"last_update"`
+}
+
+// cmdServe implementiert das "serve"-Subcommand: ein HTTP-Server, der die
+// Adressdatenbank als JSON-API bereitstellt, damit andere Dienste sie als
+// lokalen Geocoder abfragen können.
+func cmdServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	db := fs.String("db", "", "Pfad zur SQLite-Datenbank, die ausgeliefert werden soll")
+	addr := fs.String("addr", ":8080", "Adresse, auf der der HTTP-Server lauscht")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *db == "" {
+		return fmt.Errorf("fehler: -db ist erforderlich")
+	}
+
+	conn, err := sql.Open("sqlite3", "file:"+*db+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("fehler beim öffnen der datenbank: %w", err)
+	}
+	defer conn.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", handleSearch(conn))
+	mux.HandleFunc("/reverse", handleReverse(conn))
+	mux.HandleFunc("/healthz", handleHealthz(conn, *db))
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: mux,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Printf("🌐 Server läuft auf %s\n", *addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("fehler beim starten des servers: %w", err)
+	case <-quit:
+		fmt.Println("🛑 Beende Server...")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("fehler beim herunterfahren des servers: %w", err)
+	}
+
+	fmt.Println("✅ Server beendet.")
+	return nil
+}
+
+func handleSearch(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			http.Error(w, "fehler: parameter q ist erforderlich", http.StatusBadRequest)
+			return
+		}
+
+		limit := 10
+		if l := r.URL.Query().Get("limit"); l != "" {
+			parsed, err := strconv.Atoi(l)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "fehler: limit muss eine positive ganzzahl sein", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+
+		rows, err := db.Query(`
+			SELECT a.street, a.house_number, a.city, a.longitude, a.latitude,
+				highlight(address_fts, 0, '<b>', '</b>') as street_match,
+				highlight(address_fts, 1, '<b>', '</b>') as house_number_match,
+				highlight(address_fts, 2, '<b>', '</b>') as city_match,
+				rank
+			FROM address_fts
+			JOIN addresses a ON address_fts.rowid = a.id
+			WHERE address_fts MATCH ?
+			ORDER BY rank
+			LIMIT ?
+		`, query, limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("fehler bei der suche: %v", err), http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		results := make([]AddressSearchResult, 0, limit)
+		for rows.Next() {
+			var res AddressSearchResult
+			if err := rows.Scan(
+				&res.Street, &res.HouseNumber, &res.City, &res.Lon, &res.Lat,
+				&res.Highlight.Street, &res.Highlight.HouseNumber, &res.Highlight.City,
+				&res.Rank,
+			); err != nil {
+				http.Error(w, fmt.Sprintf("fehler beim scannen der ergebnisse: %v", err), http.StatusInternalServerError)
+				return
+			}
+			results = append(results, res)
+		}
+
+		writeJSON(w, results)
+	}
+}
+
+func handleReverse(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+		if err != nil {
+			http.Error(w, "fehler: parameter lat ist erforderlich", http.StatusBadRequest)
+			return
+		}
+		lon, err := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+		if err != nil {
+			http.Error(w, "fehler: parameter lon ist erforderlich", http.StatusBadRequest)
+			return
+		}
+
+		radiusMeters := 500.0
+		if radiusParam := r.URL.Query().Get("radius"); radiusParam != "" {
+			parsed, err := strconv.ParseFloat(radiusParam, 64)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "fehler: radius muss eine positive zahl sein", http.StatusBadRequest)
+				return
+			}
+			radiusMeters = parsed
+		}
+
+		matches, err := ReverseGeocode(db, lat, lon, radiusMeters, 0)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("fehler bei der umkreissuche: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		type reverseResult struct {
+			Street      string  `json:"street"`
+			HouseNumber string  `json:"house_number"`
+			City        string  `json:"city"`
+			Lon         float64 `json:"lon"`
+			Lat         float64 `json:"lat"`
+			DistanceM   float64 `json:"distance_m"`
+		}
+
+		results := make([]reverseResult, 0, len(matches))
+		for _, m := range matches {
+			results = append(results, reverseResult{
+				Street:      m.Street,
+				HouseNumber: m.HouseNumber,
+				City:        m.City,
+				Lon:         m.Lon,
+				Lat:         m.Lat,
+				DistanceM:   haversineMeters(lat, lon, m.Lat, m.Lon),
+			})
+		}
+
+		writeJSON(w, results)
+	}
+}
+
+func handleHealthz(db *sql.DB, dbFilePath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rowCount int64
+		if err := db.QueryRow("SELECT COUNT(*) FROM addresses").Scan(&rowCount); err != nil {
+			http.Error(w, fmt.Sprintf("fehler beim zählen der adressen: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		lastUpdate := lastUpdateTime(db, dbFilePath)
+
+		writeJSON(w, healthzResponse{
+			RowCount:   rowCount,
+			LastUpdate: lastUpdate,
+		})
+	}
+}
+
+// lastUpdateTime liefert den Zeitpunkt der letzten Aktualisierung: bevorzugt
+// aus sync_state (vom "update"-Subcommand gepflegt), andernfalls aus der
+// Änderungszeit der Datenbankdatei.
+func lastUpdateTime(db *sql.DB, dbFilePath string) string {
+	var timestamp string
+	err := db.QueryRow("SELECT last_timestamp FROM sync_state WHERE id = 1").Scan(&timestamp)
+	if err == nil {
+		return timestamp
+	}
+
+	info, err := os.Stat(dbFilePath)
+	if err != nil {
+		return ""
+	}
+	return info.ModTime().UTC().Format(time.RFC3339)
+}
+
+// addressMatch koppelt einen Adressdatensatz an seine Distanz zu einem
+// Referenzpunkt, z.B. für /reverse oder ReverseGeocode
+type addressMatch struct {
+	AddressRecord
+	Distance float64
+}
+
+// haversineMeters berechnet die Großkreisdistanz zwischen zwei
+// WGS84-Koordinaten in Metern
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusMeters = 6371000.0
+
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return earthRadiusMeters * c
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("fehler beim schreiben der json-antwort: %v", err)
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Basis-URL für die Geofabrik-Diff-Verzeichnisse (minütlich/stündlich/täglich),
+// siehe https://download.geofabrik.de/europe/germany/mittelfranken-updates/
+const defaultDiffBaseURL = "https://download.geofabrik.de/europe/germany/mittelfranken-updates/"
+
+// OsmChange bildet die Wurzel eines OsmChange-Dokuments (.osc) ab
+type OsmChange struct {
+	XMLName xml.Name `xml:"osmChange"`
+	Create  OsmGroup `xml:"create"`
+	Modify  OsmGroup `xml:"modify"`
+	Delete  OsmGroup `xml:"delete"`
+}
+
+// OsmGroup fasst die drei Element-Typen zusammen, die innerhalb von
+// <create>, <modify> und <delete> vorkommen können
+type OsmGroup struct {
+	Nodes     []OsmNode     `xml:"node"`
+	Ways      []OsmWay      `xml:"way"`
+	Relations []OsmRelation `xml:"relation"`
+}
+
+// OsmTag repräsentiert ein einzelnes <tag k="..." v="..."/>-Element
+type OsmTag struct {
+	K string `xml:"k,attr"`
+	V string `xml:"v,attr"`
+}
+
+// OsmNode repräsentiert ein <node>-Element eines OsmChange-Dokuments
+type OsmNode struct {
+	ID   int64    `xml:"id,attr"`
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Tags []OsmTag `xml:"tag"`
+}
+
+// OsmWay repräsentiert ein <way>-Element eines OsmChange-Dokuments
+type OsmWay struct {
+	ID   int64    `xml:"id,attr"`
+	Tags []OsmTag `xml:"tag"`
+}
+
+// OsmRelation repräsentiert ein <relation>-Element eines OsmChange-Dokuments
+type OsmRelation struct {
+	ID   int64    `xml:"id,attr"`
+	Tags []OsmTag `xml:"tag"`
+}
+
+// cmdUpdate implementiert das "update"-Subcommand: es bringt eine bereits
+// importierte Datenbank anhand von Geofabrik-.osc.gz-Diffs auf den
+// aktuellen Stand, ohne die komplette PBF-Datei erneut herunterzuladen.
+func cmdUpdate(args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	db := fs.String("db", "", "Pfad zur SQLite-Datenbank, die aktualisiert werden soll")
+	diffBaseURL := fs.String("diff-url", defaultDiffBaseURL, "Basis-URL des Geofabrik-Diff-Verzeichnisses")
+	startSequence := fs.Int64("start-sequence", 0, "sequenceNumber der state.txt, die zum importierten PBF-Extrakt gehört (nur beim allerersten Lauf erforderlich, siehe die state.txt neben der heruntergeladenen PBF-Datei)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *db == "" {
+		return fmt.Errorf("fehler: -db ist erforderlich")
+	}
+
+	if _, err := os.Stat(*db); err != nil {
+		return fmt.Errorf("fehler: datenbank %q nicht gefunden: %w", *db, err)
+	}
+
+	conn, err := sql.Open("sqlite3", *db)
+	if err != nil {
+		return fmt.Errorf("fehler beim öffnen der datenbank: %w", err)
+	}
+	defer conn.Close()
+
+	if err := ensureSyncStateTable(conn); err != nil {
+		return fmt.Errorf("fehler beim anlegen von sync_state: %w", err)
+	}
+
+	lastSequence, err := readSyncState(conn)
+	if err != nil {
+		return fmt.Errorf("fehler beim lesen von sync_state: %w", err)
+	}
+
+	remoteState, err := fetchState(*diffBaseURL + "state.txt")
+	if err != nil {
+		return fmt.Errorf("fehler beim abrufen von state.txt: %w", err)
+	}
+
+	if lastSequence == 0 {
+		// Ohne einen vom Nutzer angegebenen Startpunkt, der zur tatsächlichen
+		// PBF-Momentaufnahme passt, gäbe es eine unsichtbare Lücke zwischen
+		// Import und dem ersten "update"-Lauf. Statt das stillschweigend auf
+		// die aktuelle remote sequenceNumber zu biegen, fordern wir den
+		// Startpunkt explizit an.
+		if *startSequence == 0 {
+			return fmt.Errorf("fehler: kein vorheriger sync-status gefunden; -start-sequence muss beim ersten lauf auf die sequenceNumber der zum importierten PBF-Extrakt gehörenden state.txt gesetzt werden")
+		}
+		fmt.Printf("✔ Kein vorheriger Sync-Status gefunden, starte ab sequenceNumber %d\n", *startSequence)
+		lastSequence = *startSequence
+	}
+
+	if remoteState.SequenceNumber <= lastSequence {
+		fmt.Println("✔ Datenbank ist bereits aktuell.")
+		return nil
+	}
+
+	for seq := lastSequence + 1; seq <= remoteState.SequenceNumber; seq++ {
+		fmt.Printf("⬇ Lade Diff #%d...\n", seq)
+
+		change, err := downloadDiff(*diffBaseURL, seq)
+		if err != nil {
+			return fmt.Errorf("fehler beim laden von diff #%d: %w", seq, err)
+		}
+
+		tx, err := conn.Begin()
+		if err != nil {
+			return err
+		}
+
+		if err := applyOsmChange(tx, change); err != nil {
+			tx.Rollback()
+			if errors.Is(err, errUnresolvedWayRelationChange) {
+				return fmt.Errorf(
+					"abbruch vor diff #%d: %w; sync_state bleibt auf sequenceNumber %d stehen, "+
+						"bitte einen vollständigen reimport einplanen, bevor \"update\" erneut ausgeführt wird",
+					seq, err, lastSequence,
+				)
+			}
+			return fmt.Errorf("fehler beim anwenden von diff #%d: %w", seq, err)
+		}
+
+		if err := writeSyncStateTx(tx, seq, remoteState.Timestamp); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		lastSequence = seq
+	}
+
+	fmt.Printf("✅ Aktualisierung abgeschlossen, sequenceNumber %d erreicht.\n", remoteState.SequenceNumber)
+	return nil
+}
+
+func ensureSyncStateTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS sync_state (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		sequence_number INTEGER NOT NULL,
+		last_timestamp TEXT NOT NULL
+	);
+	`)
+	return err
+}
+
+func readSyncState(db *sql.DB) (int64, error) {
+	var seq int64
+	err := db.QueryRow("SELECT sequence_number FROM sync_state WHERE id = 1").Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+func writeSyncStateTx(tx *sql.Tx, seq int64, timestamp string) error {
+	_, err := tx.Exec(
+		"INSERT INTO sync_state (id, sequence_number, last_timestamp) VALUES (1, ?, ?) "+
+			"ON CONFLICT(id) DO UPDATE SET sequence_number = excluded.sequence_number, last_timestamp = excluded.last_timestamp",
+		seq, timestamp,
+	)
+	return err
+}
+
+// stateInfo bildet den Inhalt einer Geofabrik state.txt ab
+type stateInfo struct {
+	SequenceNumber int64
+	Timestamp      string
+}
+
+// fetchState lädt und parst eine state.txt im Java-Properties-Format
+// (sequenceNumber=..., timestamp=...)
+func fetchState(url string) (*stateInfo, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download fehler: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStateBody(string(body))
+}
+
+// parseStateBody parst den Inhalt einer state.txt im Java-Properties-Format;
+// ausgelagert aus fetchState, damit das Parsing ohne HTTP-Zugriff testbar ist.
+func parseStateBody(body string) (*stateInfo, error) {
+	state := &stateInfo{}
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "sequenceNumber":
+			seq, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("fehler beim parsen von sequenceNumber: %w", err)
+			}
+			state.SequenceNumber = seq
+		case "timestamp":
+			state.Timestamp = strings.ReplaceAll(value, "\\:", ":")
+		}
+	}
+
+	return state, nil
+}
+
+// sequencePath bildet eine sequenceNumber auf das dreistufige
+// Verzeichnisschema ab, das Geofabrik/osmosis für Diff-Dateien verwendet
+// (z.B. 1234567 -> "001/234/567")
+func sequencePath(seq int64) string {
+	s := fmt.Sprintf("%09d", seq)
+	return fmt.Sprintf("%s/%s/%s", s[0:3], s[3:6], s[6:9])
+}
+
+// downloadDiff lädt die .osc.gz-Datei zu einer sequenceNumber herunter und
+// parst sie zu einem OsmChange-Dokument
+func downloadDiff(baseURL string, seq int64) (*OsmChange, error) {
+	url := baseURL + sequencePath(seq) + ".osc.gz"
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download fehler: %s", resp.Status)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var change OsmChange
+	if err := xml.NewDecoder(gz).Decode(&change); err != nil {
+		return nil, fmt.Errorf("fehler beim parsen des osmChange-dokuments: %w", err)
+	}
+
+	return &change, nil
+}
+
+// applyOsmChange wendet ein komplettes OsmChange-Dokument auf die
+// addresses-Tabelle (und den FTS5-Index) an
+func applyOsmChange(tx *sql.Tx, change *OsmChange) error {
+	for _, n := range change.Create.Nodes {
+		if err := applyNode(tx, n, false); err != nil {
+			return err
+		}
+	}
+	for _, n := range change.Modify.Nodes {
+		if err := applyNode(tx, n, false); err != nil {
+			return err
+		}
+	}
+	for _, n := range change.Delete.Nodes {
+		if err := applyNode(tx, n, true); err != nil {
+			return err
+		}
+	}
+
+	// Ways und Relations tragen in einem OsmChange-Dokument selbst keine
+	// Koordinaten, daher kann für sie nur die Löschung einer bestehenden
+	// Adresse angewendet werden. Create/Modify mit addr:street benötigen den
+	// Node-Location- und Way-Ring-Cache aus pbf.go, der von scanPBFMapped
+	// nach jedem Lauf verworfen wird (os.Remove auf cachePath) und daher
+	// hier nicht zur Verfügung steht, um die Geometrie aufzulösen. Ways/
+	// Relations ohne addr:street betreffen addresses ohnehin nicht und
+	// werden unten nicht mitgezählt.
+	for _, w := range change.Delete.Ways {
+		if err := deleteAddress(tx, "w", w.ID); err != nil {
+			return err
+		}
+	}
+	for _, r := range change.Delete.Relations {
+		if err := deleteAddress(tx, "r", r.ID); err != nil {
+			return err
+		}
+	}
+
+	unresolved := countWaysWithAddrStreet(change.Create.Ways) + countWaysWithAddrStreet(change.Modify.Ways) +
+		countRelationsWithAddrStreet(change.Create.Relations) + countRelationsWithAddrStreet(change.Modify.Relations)
+	if unresolved > 0 {
+		return fmt.Errorf("%w: %d way(s)/relation(en) mit addr:street und create/modify, deren geometrie ohne persistenten node-cache nicht aufgelöst werden kann", errUnresolvedWayRelationChange, unresolved)
+	}
+
+	return nil
+}
+
+// countWaysWithAddrStreet zählt, wie viele der übergebenen Ways ein
+// addr:street-Tag tragen. Ways ohne diesen Tag betreffen die
+// addresses-Tabelle nicht und werden wie in extractAddressData/pbf.go
+// ignoriert, auch wenn applyOsmChange ihre Geometrie nicht auflösen kann.
+func countWaysWithAddrStreet(ways []OsmWay) int {
+	count := 0
+	for _, w := range ways {
+		if _, ok := tagMap(w.Tags)["addr:street"]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+// countRelationsWithAddrStreet ist das Relation-Äquivalent zu
+// countWaysWithAddrStreet
+func countRelationsWithAddrStreet(relations []OsmRelation) int {
+	count := 0
+	for _, r := range relations {
+		if _, ok := tagMap(r.Tags)["addr:street"]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+// errUnresolvedWayRelationChange markiert einen Diff, der way- oder
+// relation-create/modify-Elemente enthält, die applyOsmChange mangels
+// aufgelöster Geometrie nicht anwenden kann. cmdUpdate bricht beim Auftreten
+// dieses Fehlers die Schleife ab, rollt den Diff zurück und lässt
+// sync_state auf der zuletzt vollständig angewendeten sequenceNumber stehen
+// — der Diff geht damit nicht stillschweigend verloren, sondern muss durch
+// einen vollständigen Reimport nachgezogen werden, bevor "update" fortfährt.
+var errUnresolvedWayRelationChange = errors.New("nicht aufgelöste way-/relation-änderung")
+
+func tagMap(tags []OsmTag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[t.K] = t.V
+	}
+	return m
+}
+
+func addrCity(tags map[string]string) string {
+	if city, ok := tags["addr:city"]; ok {
+		return city
+	}
+	if town, ok := tags["addr:town"]; ok {
+		return town
+	}
+	if village, ok := tags["addr:village"]; ok {
+		return village
+	}
+	return ""
+}
+
+// applyNode wendet ein einzelnes create/modify/delete eines <node>-Elements
+// an: eine eventuell vorhandene alte Zeile wird zuerst aus addresses und dem
+// FTS5-Index entfernt, anschließend wird bei create/modify mit addr:street-Tag
+// die neue Zeile eingefügt.
+func applyNode(tx *sql.Tx, n OsmNode, deleted bool) error {
+	if err := deleteAddress(tx, "n", n.ID); err != nil {
+		return err
+	}
+
+	if deleted {
+		return nil
+	}
+
+	tags := tagMap(n.Tags)
+	street, hasStreet := tags["addr:street"]
+	if !hasStreet {
+		return nil
+	}
+
+	city := addrCity(tags)
+	// INSERT OR IGNORE wie bulkInsert: addresses trägt UNIQUE(street,
+	// house_number, city), daher können mehrere OSM-Objekte (z.B. doppelt
+	// erfasste Adresspunkte) kollidieren. Ein Konflikt soll den Diff nicht
+	// abbrechen, sondern diesen einzelnen Datensatz überspringen.
+	res, err := tx.Exec(
+		"INSERT OR IGNORE INTO addresses (street, house_number, city, longitude, latitude, osm_type, osm_id) VALUES (?, ?, ?, ?, ?, 'n', ?)",
+		street, tags["addr:housenumber"], city, n.Lon, n.Lat, n.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return nil
+	}
+
+	newID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO address_fts(rowid, street, house_number, city) VALUES (?, ?, ?, ?)",
+		newID, street, tags["addr:housenumber"], city,
+	); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO addresses_rtree(id, min_lon, max_lon, min_lat, max_lat) VALUES (?, ?, ?, ?, ?)",
+		newID, n.Lon, n.Lon, n.Lat, n.Lat,
+	)
+	return err
+}
+
+// deleteAddress entfernt (falls vorhanden) die zu osmType/osmID gehörende
+// Zeile aus addresses und räumt den FTS5-Index über dessen 'delete'-Befehl
+// passend dazu auf.
+func deleteAddress(tx *sql.Tx, osmType string, osmID int64) error {
+	var rowID int64
+	var street, houseNumber, city string
+
+	err := tx.QueryRow(
+		"SELECT id, street, house_number, city FROM addresses WHERE osm_type = ? AND osm_id = ?",
+		osmType, osmID,
+	).Scan(&rowID, &street, &houseNumber, &city)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO address_fts(address_fts, rowid, street, house_number, city) VALUES ('delete', ?, ?, ?, ?)",
+		rowID, street, houseNumber, city,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM addresses_rtree WHERE id = ?", rowID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM addresses WHERE id = ?", rowID)
+	return err
+}
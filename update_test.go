@@ -0,0 +1,267 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// setupUpdateTestDB legt ein addresses/address_fts/addresses_rtree-Schema
+// in einer In-Memory-Datenbank an, wie es createMappedTables für die
+// addresses-Tabelle erzeugt, und macht es für applyOsmChange/applyNode
+// nutzbar.
+func setupUpdateTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`
+		CREATE TABLE addresses (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			street TEXT,
+			house_number TEXT,
+			city TEXT,
+			longitude REAL,
+			latitude REAL,
+			osm_type CHAR(1),
+			osm_id INTEGER,
+			UNIQUE(street, house_number, city)
+		);
+		CREATE UNIQUE INDEX idx_addresses_osm ON addresses(osm_type, osm_id);
+		CREATE VIRTUAL TABLE address_fts USING fts5(
+			street, house_number, city,
+			content='addresses',
+			content_rowid='id'
+		);
+		CREATE VIRTUAL TABLE addresses_rtree USING rtree(id, min_lon, max_lon, min_lat, max_lat);
+	`); err != nil {
+		t.Fatalf("schema anlegen: %v", err)
+	}
+
+	return db
+}
+
+func countAddresses(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM addresses").Scan(&count); err != nil {
+		t.Fatalf("count addresses: %v", err)
+	}
+	return count
+}
+
+func TestApplyNodeCreateAndDelete(t *testing.T) {
+	db := setupUpdateTestDB(t)
+
+	node := OsmNode{
+		ID: 1, Lat: 48.1372, Lon: 11.5755,
+		Tags: []OsmTag{{K: "addr:street", V: "Marienplatz"}, {K: "addr:housenumber", V: "1"}},
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := applyNode(tx, node, false); err != nil {
+		t.Fatalf("applyNode(create): %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if got := countAddresses(t, db); got != 1 {
+		t.Fatalf("countAddresses() = %d, want 1 nach create", got)
+	}
+
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := applyNode(tx, node, true); err != nil {
+		t.Fatalf("applyNode(delete): %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if got := countAddresses(t, db); got != 0 {
+		t.Fatalf("countAddresses() = %d, want 0 nach delete", got)
+	}
+}
+
+func TestApplyNodeWithoutAddrStreetIsIgnored(t *testing.T) {
+	db := setupUpdateTestDB(t)
+
+	node := OsmNode{ID: 1, Lat: 48.1372, Lon: 11.5755, Tags: []OsmTag{{K: "amenity", V: "cafe"}}}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := applyNode(tx, node, false); err != nil {
+		t.Fatalf("applyNode: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if got := countAddresses(t, db); got != 0 {
+		t.Fatalf("countAddresses() = %d, want 0 für ein objekt ohne addr:street", got)
+	}
+}
+
+func TestApplyOsmChangeIgnoresWayRelationWithoutAddrStreet(t *testing.T) {
+	db := setupUpdateTestDB(t)
+
+	change := &OsmChange{
+		Modify: OsmGroup{
+			Ways:      []OsmWay{{ID: 1, Tags: []OsmTag{{K: "highway", V: "residential"}}}},
+			Relations: []OsmRelation{{ID: 2, Tags: []OsmTag{{K: "type", V: "multipolygon"}}}},
+		},
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := applyOsmChange(tx, change); err != nil {
+		t.Fatalf("applyOsmChange() = %v, want kein fehler für way/relation ohne addr:street", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+func TestApplyOsmChangeAbortsOnWayWithAddrStreet(t *testing.T) {
+	db := setupUpdateTestDB(t)
+
+	change := &OsmChange{
+		Create: OsmGroup{
+			Ways: []OsmWay{{ID: 1, Tags: []OsmTag{{K: "addr:street", V: "Hauptstraße"}}}},
+		},
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = applyOsmChange(tx, change)
+	if err == nil {
+		t.Fatal("applyOsmChange() = nil, want fehler für way-create mit addr:street")
+	}
+	if !errors.Is(err, errUnresolvedWayRelationChange) {
+		t.Fatalf("applyOsmChange() fehler = %v, want errUnresolvedWayRelationChange", err)
+	}
+}
+
+func TestApplyOsmChangeDeletesWayAndRelationRegardlessOfTags(t *testing.T) {
+	db := setupUpdateTestDB(t)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := applyNode(tx, OsmNode{ID: 1, Lat: 1, Lon: 1, Tags: []OsmTag{{K: "addr:street", V: "x"}}}, false); err != nil {
+		t.Fatalf("seed node: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit seed: %v", err)
+	}
+
+	if _, err := db.Exec("UPDATE addresses SET osm_type = 'w', osm_id = 42 WHERE osm_type = 'n'"); err != nil {
+		t.Fatalf("reassign osm_type for fixture: %v", err)
+	}
+
+	change := &OsmChange{
+		Delete: OsmGroup{
+			Ways: []OsmWay{{ID: 42}},
+		},
+	}
+
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := applyOsmChange(tx, change); err != nil {
+		t.Fatalf("applyOsmChange(delete way): %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if got := countAddresses(t, db); got != 0 {
+		t.Fatalf("countAddresses() = %d, want 0 nach delete des way", got)
+	}
+}
+
+func TestFetchState(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantSeq int64
+		wantTS  string
+		wantErr bool
+	}{
+		{
+			name:    "gültige state.txt",
+			body:    "#comment\ntimestamp=2024-01-02T03\\:04\\:05Z\nsequenceNumber=1234567\n",
+			wantSeq: 1234567,
+			wantTS:  "2024-01-02T03:04:05Z",
+		},
+		{
+			name:    "ungültige sequenceNumber",
+			body:    "sequenceNumber=abc\n",
+			wantErr: true,
+		},
+		{
+			name:    "leere zeilen und unbekannte keys werden ignoriert",
+			body:    "\nfoo=bar\nsequenceNumber=1\ntimestamp=2024-01-01T00\\:00\\:00Z\n",
+			wantSeq: 1,
+			wantTS:  "2024-01-01T00:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := parseStateBody(tt.body)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("parseStateBody() = nil, want fehler")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStateBody(): %v", err)
+			}
+			if state.SequenceNumber != tt.wantSeq || state.Timestamp != tt.wantTS {
+				t.Fatalf("state = %+v, want {%d %q}", state, tt.wantSeq, tt.wantTS)
+			}
+		})
+	}
+}
+
+func TestSequencePath(t *testing.T) {
+	tests := []struct {
+		seq  int64
+		want string
+	}{
+		{1234567, "001/234/567"},
+		{0, "000/000/000"},
+		{9, "000/000/009"},
+		{999999999, "999/999/999"},
+	}
+
+	for _, tt := range tests {
+		if got := sequencePath(tt.seq); got != tt.want {
+			t.Errorf("sequencePath(%d) = %q, want %q", tt.seq, got, tt.want)
+		}
+	}
+}